@@ -1,13 +1,18 @@
 package rpc_demo
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net"
+	"net/http"
+	"strings"
 	"sync"
+	"time"
 
 	"rpc_demo/codec"
 )
@@ -171,22 +176,96 @@ func parseOption(opts ...*Option) (*Option, error) {
 	return opt, nil
 }
 
-// Dial 便于用户输入server地址，创建Client实例
-func Dial(network, address string, opts ...*Option) (client *Client, err error) {
+// newClientFunc 在已建立的conn上完成协议握手、创建Client实例，Dial/DialHTTP各自传入不同实现
+type newClientFunc func(conn net.Conn, opt *Option) (client *Client, err error)
+
+// dialTimeout 建立网络连接（受ConnectTimeout限制），并在goroutine中执行f完成握手，
+// 握手耗时超过ConnectTimeout时返回超时错误，避免conn泄漏
+func dialTimeout(f newClientFunc, network, address string, opts ...*Option) (client *Client, err error) {
 	opt, err := parseOption(opts...)
 	if err != nil {
 		return nil, err
 	}
-	conn, err := net.Dial(network, address)
+	conn, err := net.DialTimeout(network, address, opt.ConnectTimeout)
 	if err != nil {
 		return nil, err
 	}
 	defer func() {
-		if client == nil {
+		if err != nil {
 			_ = conn.Close()
 		}
 	}()
-	return NewClient(conn, opt)
+	type clientResult struct {
+		client *Client
+		err    error
+	}
+	// ch必须带缓冲：超时分支一旦命中time.After就不会再接收ch，
+	// 若是无缓冲的，握手goroutine会永久阻塞在对应的send上而泄漏
+	ch := make(chan clientResult, 1)
+	go func() {
+		client, err := f(conn, opt)
+		ch <- clientResult{client: client, err: err}
+	}()
+	if opt.ConnectTimeout == 0 {
+		result := <-ch
+		return result.client, result.err
+	}
+	select {
+	case <-time.After(opt.ConnectTimeout):
+		return nil, fmt.Errorf("rpc client: connect timeout: expect within %s", opt.ConnectTimeout)
+	case result := <-ch:
+		return result.client, result.err
+	}
+}
+
+// Dial 便于用户输入server地址，创建Client实例
+func Dial(network, address string, opts ...*Option) (*Client, error) {
+	return dialTimeout(NewClient, network, address, opts...)
+}
+
+// NewHTTPClient 通过HTTP协议的CONNECT方式，在conn上建立一条RPC连接后创建Client实例；
+// rpcPath必须和server端HandleHTTP注册的rpcPath一致，否则无法命中对应的Handler
+func NewHTTPClient(conn net.Conn, rpcPath string, opt *Option) (*Client, error) {
+	_, _ = io.WriteString(conn, fmt.Sprintf("CONNECT %s HTTP/1.0\n\n", rpcPath))
+
+	// 在切换到RPC协议之前，先确认server已接受CONNECT请求
+	resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: "CONNECT"})
+	if err == nil && resp.Status == connected {
+		return NewClient(conn, opt)
+	}
+	if err == nil {
+		err = errors.New("unexpected HTTP response: " + resp.Status)
+	}
+	return nil, err
+}
+
+// DialHTTPPath 连接到监听在network、address上、挂载了RPC的HTTP server，
+// rpcPath为server端HandleHTTP注册RPC请求所用的路径
+func DialHTTPPath(network, address, rpcPath string, opts ...*Option) (*Client, error) {
+	return dialTimeout(func(conn net.Conn, opt *Option) (*Client, error) {
+		return NewHTTPClient(conn, rpcPath, opt)
+	}, network, address, opts...)
+}
+
+// DialHTTP 连接到监听在network、address上、挂载了RPC的HTTP server，rpcPath使用默认值
+func DialHTTP(network, address string, opts ...*Option) (*Client, error) {
+	return DialHTTPPath(network, address, defaultRPCPath, opts...)
+}
+
+// XDial 根据rpcAddr（形如 protocol@addr，如 http@10.0.0.1:7001 或 tcp@10.0.0.1:7001）
+// 选择DialHTTP或Dial，方便XClient按服务发现返回的地址统一建连
+func XDial(rpcAddr string, opts ...*Option) (*Client, error) {
+	parts := strings.Split(rpcAddr, "@")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("rpc client err: wrong format '%s', expect protocol@addr", rpcAddr)
+	}
+	protocol, addr := parts[0], parts[1]
+	switch protocol {
+	case "http":
+		return DialHTTP("tcp", addr, opts...)
+	default:
+		return Dial(protocol, addr, opts...)
+	}
 }
 
 // 发送请求至server
@@ -235,8 +314,19 @@ func (client *Client) Go(serviceMethod string, args, reply interface{}, done cha
 	return call
 }
 
-// Call 同步接口，阻塞直到resp返回
+// Call 同步接口，阻塞直到resp返回，等价于CallContext(context.Background(), ...)
 func (client *Client) Call(serviceMethod string, args, reply interface{}) error {
-	call := <-client.Go(serviceMethod, args, reply, make(chan *Call, 1)).Done
-	return call.Error
+	return client.CallContext(context.Background(), serviceMethod, args, reply)
+}
+
+// CallContext 同Call，但受ctx控制，ctx取消或超时后立即返回，并从pending中移除对应的call
+func (client *Client) CallContext(ctx context.Context, serviceMethod string, args, reply interface{}) error {
+	call := client.Go(serviceMethod, args, reply, make(chan *Call, 1))
+	select {
+	case <-ctx.Done():
+		client.removeCall(call.Seq)
+		return errors.New("rpc client: call failed: " + ctx.Err().Error())
+	case call := <-call.Done:
+		return call.Error
+	}
 }
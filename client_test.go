@@ -1,7 +1,6 @@
 package rpc_demo
 
 import (
-	"fmt"
 	"log"
 	"sync"
 	"testing"
@@ -27,8 +26,8 @@ func TestClient_Call(t *testing.T) {
 				wg.Add(1)
 				go func(i int) {
 					defer wg.Done()
-					args := fmt.Sprintf("rpc req %d", i)
-					var reply string
+					args := Args{Num1: i, Num2: i * i}
+					var reply int
 					if err := client.Call("Foo.Sum", args, &reply); err != nil {
 						log.Fatal("call Foo.Sum error:", err)
 					}
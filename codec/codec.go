@@ -0,0 +1,38 @@
+package codec
+
+import "io"
+
+// Header 一次RPC调用的消息头
+type Header struct {
+	ServiceMethod string // 服务名.方法名，与 Go 中的方法映射关系一致
+	Seq           uint64 // 请求序号，用来区分不同请求
+	Error         string // 错误信息，客户端置为空，服务端如果发生错误，将错误信息置于此字段中
+}
+
+// Codec 对消息体进行编解码的接口，抽象出接口是为了实现不同的Codec实例
+type Codec interface {
+	io.Closer
+	ReadHeader(*Header) error
+	ReadBody(interface{}) error
+	Write(*Header, interface{}) error
+}
+
+// NewCodecFunc Codec的构造函数，客户端和服务端可以通过 Codec 的 Type 得到构造函数，从而创建 Codec 实例
+type NewCodecFunc func(io.ReadWriteCloser) Codec
+
+// Type 编解码器类型
+type Type string
+
+const (
+	JsonType     Type = "application/json"
+	ProtobufType Type = "application/protobuf"
+)
+
+// NewCodecFuncMap 存储 Type 与对应构造函数的映射关系
+var NewCodecFuncMap map[Type]NewCodecFunc
+
+func init() {
+	NewCodecFuncMap = make(map[Type]NewCodecFunc)
+	NewCodecFuncMap[JsonType] = NewJsonCodec
+	NewCodecFuncMap[ProtobufType] = NewProtobufCodec
+}
@@ -0,0 +1,17 @@
+// 手写的header.proto对应类型，并非protoc-gen-go生成：只实现了proto.Message所需的
+// Reset/String/ProtoMessage，没有文件描述符，也没有注册到proto类型表
+
+package pb
+
+import "fmt"
+
+// Header 对应codec.Header，供ProtobufCodec序列化消息头使用
+type Header struct {
+	ServiceMethod string `protobuf:"bytes,1,opt,name=service_method,json=serviceMethod,proto3" json:"service_method,omitempty"`
+	Seq           uint64 `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
+	Error         string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (m *Header) Reset()         { *m = Header{} }
+func (m *Header) String() string { return fmt.Sprintf("%+v", *m) }
+func (*Header) ProtoMessage()    {}
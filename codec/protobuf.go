@@ -0,0 +1,133 @@
+package codec
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/golang/protobuf/proto"
+
+	"rpc_demo/codec/pb"
+)
+
+// maxFrameSize 单个帧允许的最大长度，防止长度前缀被污染或协议不匹配时引发过大内存分配
+const maxFrameSize = 64 << 20
+
+// ProtobufCodec header和body均用protobuf编码，各自采用
+// "4字节大端长度前缀 + 内容" 的方式分帧，因此不依赖Option里约定的JSON分隔方式
+type ProtobufCodec struct {
+	conn io.ReadWriteCloser
+	buf  *bufio.Writer
+}
+
+func NewProtobufCodec(conn io.ReadWriteCloser) Codec {
+	return &ProtobufCodec{
+		conn: conn,
+		buf:  bufio.NewWriter(conn),
+	}
+}
+
+func (c *ProtobufCodec) Close() error {
+	return c.conn.Close()
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var length uint32
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	if length > maxFrameSize {
+		return nil, fmt.Errorf("rpc codec: protobuf frame too large: %d bytes", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func writeFrame(w io.Writer, data []byte) error {
+	if err := binary.Write(w, binary.BigEndian, uint32(len(data))); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+func (c *ProtobufCodec) ReadHeader(h *Header) error {
+	data, err := readFrame(c.conn)
+	if err != nil {
+		return err
+	}
+	var ph pb.Header
+	if err := proto.Unmarshal(data, &ph); err != nil {
+		return err
+	}
+	h.ServiceMethod = ph.ServiceMethod
+	h.Seq = ph.Seq
+	h.Error = ph.Error
+	return nil
+}
+
+func (c *ProtobufCodec) ReadBody(body interface{}) error {
+	data, err := readFrame(c.conn)
+	if err != nil {
+		return err
+	}
+	if body == nil {
+		return nil
+	}
+	msg, ok := body.(proto.Message)
+	if !ok {
+		return errors.New("rpc codec: protobuf body must implement proto.Message")
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+func (c *ProtobufCodec) Write(h *Header, body interface{}) (err error) {
+	defer func() {
+		_ = c.buf.Flush()
+		if err != nil {
+			_ = c.Close()
+		}
+	}()
+
+	// server在响应里携带错误时，body是server.go里的invalidRequest占位符(struct{}{})，
+	// 不实现proto.Message，此时没有实际负载可编码，发一个空帧即可，不应视为错误；
+	// 其他不实现proto.Message的body仍按约定报错，而不是静默编码成空帧
+	var bodyData []byte
+	switch v := body.(type) {
+	case nil, struct{}:
+		// 无负载
+	case proto.Message:
+		if bodyData, err = proto.Marshal(v); err != nil {
+			log.Println("rpc codec: protobuf error encoding body: ", err)
+			return err
+		}
+	default:
+		err = errors.New("rpc codec: protobuf body must implement proto.Message")
+		log.Println("rpc codec: protobuf error encoding body: ", err)
+		return err
+	}
+
+	ph := &pb.Header{ServiceMethod: h.ServiceMethod, Seq: h.Seq, Error: h.Error}
+	headerData, err := proto.Marshal(ph)
+	if err != nil {
+		log.Println("rpc codec: protobuf error encoding header: ", err)
+		return err
+	}
+	if err = writeFrame(c.buf, headerData); err != nil {
+		log.Println("rpc codec: protobuf error writing header: ", err)
+		return err
+	}
+	if err = writeFrame(c.buf, bodyData); err != nil {
+		log.Println("rpc codec: protobuf error writing body: ", err)
+		return err
+	}
+	return nil
+}
+
+var _ Codec = (*ProtobufCodec)(nil)
@@ -0,0 +1,95 @@
+package codec
+
+import (
+	"io"
+	"net"
+	"testing"
+
+	"rpc_demo/codec/pb"
+)
+
+// discardReadWriteCloser 丢弃所有写入，Read直接返回EOF，benchmark只关心Write的编码开销
+type discardReadWriteCloser struct{}
+
+func (discardReadWriteCloser) Read([]byte) (int, error)    { return 0, io.EOF }
+func (discardReadWriteCloser) Write(p []byte) (int, error) { return len(p), nil }
+func (discardReadWriteCloser) Close() error                { return nil }
+
+// notProtoMessage 不实现proto.Message，用于验证Write对非法body的报错
+type notProtoMessage struct {
+	Foo string
+}
+
+func TestProtobufCodec_RoundTrip(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	serverCodec := NewProtobufCodec(serverConn)
+	clientCodec := NewProtobufCodec(clientConn)
+
+	h := &Header{ServiceMethod: "Foo.Sum", Seq: 42}
+	body := &pb.Header{ServiceMethod: "Foo.Sum", Seq: 42}
+
+	writeErr := make(chan error, 1)
+	go func() { writeErr <- serverCodec.Write(h, body) }()
+
+	var gotHeader Header
+	if err := clientCodec.ReadHeader(&gotHeader); err != nil {
+		t.Fatalf("ReadHeader: %v", err)
+	}
+	var gotBody pb.Header
+	if err := clientCodec.ReadBody(&gotBody); err != nil {
+		t.Fatalf("ReadBody: %v", err)
+	}
+	if err := <-writeErr; err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if gotHeader != *h {
+		t.Fatalf("header mismatch: got %+v, want %+v", gotHeader, *h)
+	}
+	if gotBody.ServiceMethod != body.ServiceMethod || gotBody.Seq != body.Seq {
+		t.Fatalf("body mismatch: got %+v, want %+v", gotBody, body)
+	}
+}
+
+// TestProtobufCodec_Write_InvalidRequestPlaceholder server发送错误响应时，
+// body是server.go里的invalidRequest占位符（struct{}{}），不应报错
+func TestProtobufCodec_Write_InvalidRequestPlaceholder(t *testing.T) {
+	c := NewProtobufCodec(discardReadWriteCloser{})
+	h := &Header{ServiceMethod: "Foo.Sum", Seq: 1, Error: "boom"}
+	if err := c.Write(h, struct{}{}); err != nil {
+		t.Fatalf("invalidRequest placeholder should encode without error, got: %v", err)
+	}
+}
+
+// TestProtobufCodec_Write_RejectsNonProtoBody 真正不支持的body类型必须报错，
+// 而不是静默编码成空帧
+func TestProtobufCodec_Write_RejectsNonProtoBody(t *testing.T) {
+	c := NewProtobufCodec(discardReadWriteCloser{})
+	h := &Header{ServiceMethod: "Foo.Sum", Seq: 1}
+	if err := c.Write(h, notProtoMessage{Foo: "bar"}); err == nil {
+		t.Fatal("expected an error for a body that doesn't implement proto.Message, got nil")
+	}
+}
+
+func BenchmarkJsonCodec_Write(b *testing.B) {
+	c := NewJsonCodec(discardReadWriteCloser{})
+	h := &Header{ServiceMethod: "Foo.Sum", Seq: 1}
+	body := &pb.Header{ServiceMethod: "Foo.Sum", Seq: 1}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = c.Write(h, body)
+	}
+}
+
+func BenchmarkProtobufCodec_Write(b *testing.B) {
+	c := NewProtobufCodec(discardReadWriteCloser{})
+	h := &Header{ServiceMethod: "Foo.Sum", Seq: 1}
+	body := &pb.Header{ServiceMethod: "Foo.Sum", Seq: 1}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = c.Write(h, body)
+	}
+}
@@ -0,0 +1,85 @@
+package loadbalancer
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// SelectMode 服务实例的选择策略
+type SelectMode int
+
+const (
+	RandomSelect     SelectMode = iota // 随机选择一个实例
+	RoundRobinSelect                   // 轮询选择实例
+)
+
+// Discovery 服务发现接口，负责维护、选择可用的服务地址
+type Discovery interface {
+	Refresh() error // 从注册中心刷新服务列表
+	Update(servers []string) error
+	Get(mode SelectMode) (string, error)
+	GetAll() ([]string, error)
+}
+
+var _ Discovery = (*MultiServersDiscovery)(nil)
+
+// MultiServersDiscovery 不依赖注册中心，服务列表由用户显式传入并维护
+type MultiServersDiscovery struct {
+	r       *rand.Rand
+	mu      sync.Mutex
+	servers []string
+	index   int // 记录轮询策略已经轮询到的位置
+}
+
+// NewMultiServerDiscovery 创建一个静态服务列表的Discovery实例
+func NewMultiServerDiscovery(servers []string) *MultiServersDiscovery {
+	d := &MultiServersDiscovery{
+		servers: servers,
+		r:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	// 为了避免每次从0开始轮询，初始化一个随机值
+	d.index = d.r.Intn(math.MaxInt32 - 1)
+	return d
+}
+
+// Refresh 静态服务列表无需刷新
+func (d *MultiServersDiscovery) Refresh() error {
+	return nil
+}
+
+func (d *MultiServersDiscovery) Update(servers []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.servers = servers
+	return nil
+}
+
+func (d *MultiServersDiscovery) Get(mode SelectMode) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := len(d.servers)
+	if n == 0 {
+		return "", errors.New("rpc discovery: no available servers")
+	}
+	switch mode {
+	case RandomSelect:
+		return d.servers[d.r.Intn(n)], nil
+	case RoundRobinSelect:
+		s := d.servers[d.index%n]
+		d.index = (d.index + 1) % n
+		return s, nil
+	default:
+		return "", errors.New("rpc discovery: not supported select mode")
+	}
+}
+
+func (d *MultiServersDiscovery) GetAll() ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	servers := make([]string, len(d.servers))
+	copy(servers, d.servers)
+	return servers, nil
+}
@@ -0,0 +1,76 @@
+package loadbalancer
+
+import "testing"
+
+func TestMultiServersDiscovery_RandomSelect(t *testing.T) {
+	servers := []string{"tcp@localhost:1001", "tcp@localhost:1002", "tcp@localhost:1003"}
+	d := NewMultiServerDiscovery(servers)
+	for i := 0; i < 10; i++ {
+		addr, err := d.Get(RandomSelect)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if !contains(servers, addr) {
+			t.Fatalf("Get returned unknown server %q", addr)
+		}
+	}
+}
+
+func TestMultiServersDiscovery_RoundRobinSelect(t *testing.T) {
+	servers := []string{"tcp@localhost:1001", "tcp@localhost:1002", "tcp@localhost:1003"}
+	d := NewMultiServerDiscovery(servers)
+	d.index = 0
+	for i := 0; i < len(servers)*2; i++ {
+		addr, err := d.Get(RoundRobinSelect)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if want := servers[i%len(servers)]; addr != want {
+			t.Fatalf("round %d: got %q, want %q", i, addr, want)
+		}
+	}
+}
+
+func TestMultiServersDiscovery_UnsupportedMode(t *testing.T) {
+	d := NewMultiServerDiscovery([]string{"tcp@localhost:1001"})
+	if _, err := d.Get(SelectMode(99)); err == nil {
+		t.Fatal("expected an error for an unsupported select mode")
+	}
+}
+
+func TestMultiServersDiscovery_NoServers(t *testing.T) {
+	d := NewMultiServerDiscovery(nil)
+	if _, err := d.Get(RandomSelect); err == nil {
+		t.Fatal("expected an error when no servers are registered")
+	}
+}
+
+func TestMultiServersDiscovery_UpdateAndGetAll(t *testing.T) {
+	d := NewMultiServerDiscovery([]string{"tcp@localhost:1001"})
+	if err := d.Update([]string{"tcp@localhost:2001", "tcp@localhost:2002"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err := d.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(got) != 2 || got[0] != "tcp@localhost:2001" || got[1] != "tcp@localhost:2002" {
+		t.Fatalf("GetAll = %v, want [tcp@localhost:2001 tcp@localhost:2002]", got)
+	}
+
+	// GetAll必须返回拷贝，调用方修改不能影响内部状态
+	got[0] = "mutated"
+	again, _ := d.GetAll()
+	if again[0] != "tcp@localhost:2001" {
+		t.Fatalf("GetAll leaked its internal slice: got %v", again)
+	}
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package loadbalancer
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"sync"
+
+	rpc "rpc_demo"
+)
+
+// XClient 复用多条到不同server的连接，按Discovery选出的地址做负载均衡调用
+type XClient struct {
+	d       Discovery
+	mode    SelectMode
+	opt     *rpc.Option
+	mu      sync.Mutex // 保护clients
+	clients map[string]*rpc.Client
+}
+
+var _ io.Closer = (*XClient)(nil)
+
+func NewXClient(d Discovery, mode SelectMode, opt *rpc.Option) *XClient {
+	return &XClient{
+		d:       d,
+		mode:    mode,
+		opt:     opt,
+		clients: make(map[string]*rpc.Client),
+	}
+}
+
+func (xc *XClient) Close() error {
+	xc.mu.Lock()
+	defer xc.mu.Unlock()
+	for key, client := range xc.clients {
+		_ = client.Close()
+		delete(xc.clients, key)
+	}
+	return nil
+}
+
+// dial 复用rpcAddr对应的连接，连接失效则重新拨号
+func (xc *XClient) dial(rpcAddr string) (*rpc.Client, error) {
+	xc.mu.Lock()
+	defer xc.mu.Unlock()
+	client, ok := xc.clients[rpcAddr]
+	if ok && !client.IsAvailable() {
+		_ = client.Close()
+		delete(xc.clients, rpcAddr)
+		client = nil
+	}
+	if client == nil {
+		var err error
+		client, err = rpc.XDial(rpcAddr, xc.opt)
+		if err != nil {
+			return nil, err
+		}
+		xc.clients[rpcAddr] = client
+	}
+	return client, nil
+}
+
+func (xc *XClient) call(rpcAddr string, ctx context.Context, serviceMethod string, args, reply interface{}) error {
+	client, err := xc.dial(rpcAddr)
+	if err != nil {
+		return err
+	}
+	return client.CallContext(ctx, serviceMethod, args, reply)
+}
+
+// Call 按SelectMode选出一个server并调用
+func (xc *XClient) Call(ctx context.Context, serviceMethod string, args, reply interface{}) error {
+	rpcAddr, err := xc.d.Get(xc.mode)
+	if err != nil {
+		return err
+	}
+	return xc.call(rpcAddr, ctx, serviceMethod, args, reply)
+}
+
+// Broadcast 并发调用Discovery中的所有server，任意一个出错就取消其余调用，
+// reply被置为第一个成功返回的结果
+func (xc *XClient) Broadcast(ctx context.Context, serviceMethod string, args, reply interface{}) error {
+	servers, err := xc.d.GetAll()
+	if err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	var mu sync.Mutex // 保护下面的e和replyDone
+	var e error
+	replyDone := reply == nil // reply为nil表示调用方不关心结果
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for _, rpcAddr := range servers {
+		wg.Add(1)
+		go func(rpcAddr string) {
+			defer wg.Done()
+			var clonedReply interface{}
+			if reply != nil {
+				clonedReply = reflect.New(reflect.ValueOf(reply).Elem().Type()).Interface()
+			}
+			err := xc.call(rpcAddr, ctx, serviceMethod, args, clonedReply)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil && e == nil {
+				e = err
+				cancel() // 任意一个调用失败，取消其余尚未返回的调用
+			}
+			if err == nil && !replyDone {
+				reflect.ValueOf(reply).Elem().Set(reflect.ValueOf(clonedReply).Elem())
+				replyDone = true
+			}
+		}(rpcAddr)
+	}
+	wg.Wait()
+	return e
+}
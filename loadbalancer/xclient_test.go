@@ -0,0 +1,78 @@
+package loadbalancer
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	rpc "rpc_demo"
+)
+
+// Foo 测试用的RPC服务
+type Foo int
+
+type Args struct {
+	Num1, Num2 int
+}
+
+func (f Foo) Sum(args Args, reply *int) error {
+	*reply = args.Num1 + args.Num2
+	return nil
+}
+
+func startXClientTestServer(t *testing.T) string {
+	t.Helper()
+	var foo Foo
+	server := rpc.NewServer()
+	if err := server.Register(&foo); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go server.Accept(l)
+	return l.Addr().String()
+}
+
+func TestXClient_Call(t *testing.T) {
+	addr := startXClientTestServer(t)
+	time.Sleep(100 * time.Millisecond)
+
+	d := NewMultiServerDiscovery([]string{"tcp@" + addr})
+	xc := NewXClient(d, RandomSelect, nil)
+	defer xc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var reply int
+	if err := xc.Call(ctx, "Foo.Sum", Args{Num1: 3, Num2: 4}, &reply); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if reply != 7 {
+		t.Fatalf("reply = %d, want 7", reply)
+	}
+}
+
+func TestXClient_Broadcast(t *testing.T) {
+	addr1 := startXClientTestServer(t)
+	addr2 := startXClientTestServer(t)
+	time.Sleep(100 * time.Millisecond)
+
+	d := NewMultiServerDiscovery([]string{"tcp@" + addr1, "tcp@" + addr2})
+	xc := NewXClient(d, RandomSelect, nil)
+	defer xc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var reply int
+	if err := xc.Broadcast(ctx, "Foo.Sum", Args{Num1: 1, Num2: 2}, &reply); err != nil {
+		t.Fatalf("Broadcast: %v", err)
+	}
+	if reply != 3 {
+		t.Fatalf("reply = %d, want 3", reply)
+	}
+}
@@ -0,0 +1,82 @@
+package registry
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"rpc_demo/loadbalancer"
+)
+
+const defaultUpdateTimeout = time.Second * 10
+
+// RegistryDiscovery 在MultiServersDiscovery之上增加了按需向registry拉取server列表的能力
+type RegistryDiscovery struct {
+	*loadbalancer.MultiServersDiscovery
+	registry   string
+	timeout    time.Duration // 服务列表的过期时间，超过就触发Refresh
+	mu         sync.Mutex
+	lastUpdate time.Time
+}
+
+// NewRegistryDiscovery timeout为0时使用defaultUpdateTimeout
+func NewRegistryDiscovery(registryAddr string, timeout time.Duration) *RegistryDiscovery {
+	if timeout == 0 {
+		timeout = defaultUpdateTimeout
+	}
+	return &RegistryDiscovery{
+		MultiServersDiscovery: loadbalancer.NewMultiServerDiscovery(make([]string, 0)),
+		registry:              registryAddr,
+		timeout:               timeout,
+	}
+}
+
+func (d *RegistryDiscovery) Update(servers []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.MultiServersDiscovery.Update(servers); err != nil {
+		return err
+	}
+	d.lastUpdate = time.Now()
+	return nil
+}
+
+// Refresh 缓存的服务列表过期后才向registry发起一次拉取
+func (d *RegistryDiscovery) Refresh() error {
+	d.mu.Lock()
+	if d.lastUpdate.Add(d.timeout).After(time.Now()) {
+		d.mu.Unlock()
+		return nil
+	}
+	d.mu.Unlock()
+
+	log.Println("rpc registry: refresh servers from registry", d.registry)
+	resp, err := http.Get(d.registry)
+	if err != nil {
+		log.Println("rpc registry refresh err:", err)
+		return err
+	}
+	var servers []string
+	for _, s := range strings.Split(resp.Header.Get("X-Rpc-Servers"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			servers = append(servers, s)
+		}
+	}
+	return d.Update(servers)
+}
+
+func (d *RegistryDiscovery) Get(mode loadbalancer.SelectMode) (string, error) {
+	if err := d.Refresh(); err != nil {
+		return "", err
+	}
+	return d.MultiServersDiscovery.Get(mode)
+}
+
+func (d *RegistryDiscovery) GetAll() ([]string, error) {
+	if err := d.Refresh(); err != nil {
+		return nil, err
+	}
+	return d.MultiServersDiscovery.GetAll()
+}
@@ -0,0 +1,73 @@
+package registry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeRegistryHandler 用固定的X-Rpc-Servers响应头模拟registry，
+// 不依赖Registry本身，单独验证RegistryDiscovery的拉取/缓存逻辑
+type fakeRegistryHandler struct {
+	servers string
+	gets    int
+}
+
+func (h *fakeRegistryHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h.gets++
+	w.Header().Set("X-Rpc-Servers", h.servers)
+}
+
+func TestRegistryDiscovery_RefreshFetchesFromRegistry(t *testing.T) {
+	handler := &fakeRegistryHandler{servers: "tcp@localhost:3001,tcp@localhost:3002"}
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	d := NewRegistryDiscovery(ts.URL, time.Minute)
+	got, err := d.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(got) != 2 || got[0] != "tcp@localhost:3001" || got[1] != "tcp@localhost:3002" {
+		t.Fatalf("GetAll = %v, want [tcp@localhost:3001 tcp@localhost:3002]", got)
+	}
+	if handler.gets != 1 {
+		t.Fatalf("registry was hit %d times, want 1", handler.gets)
+	}
+}
+
+func TestRegistryDiscovery_CachesWithinTimeout(t *testing.T) {
+	handler := &fakeRegistryHandler{servers: "tcp@localhost:3001"}
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	d := NewRegistryDiscovery(ts.URL, time.Minute)
+	if _, err := d.GetAll(); err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if _, err := d.GetAll(); err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if handler.gets != 1 {
+		t.Fatalf("registry was hit %d times within the cache window, want 1", handler.gets)
+	}
+}
+
+func TestRegistryDiscovery_RefetchesAfterTimeout(t *testing.T) {
+	handler := &fakeRegistryHandler{servers: "tcp@localhost:3001"}
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	d := NewRegistryDiscovery(ts.URL, 20*time.Millisecond)
+	if _, err := d.GetAll(); err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if _, err := d.GetAll(); err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if handler.gets != 2 {
+		t.Fatalf("registry was hit %d times, want 2 after the cache expired", handler.gets)
+	}
+}
@@ -0,0 +1,121 @@
+package registry
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Registry 一个简单的、基于心跳探活的注册中心，协议为纯HTTP，方便用curl调试
+type Registry struct {
+	timeout time.Duration // 超过timeout没有收到心跳的server视为不可用
+	mu      sync.Mutex
+	servers map[string]*serverItem
+}
+
+type serverItem struct {
+	Addr  string
+	start time.Time // 最近一次收到心跳的时间
+}
+
+const (
+	defaultPath    = "/_rpc_/registry"
+	defaultTimeout = time.Minute * 5
+)
+
+// New 创建一个指定超时时间的注册中心实例
+func New(timeout time.Duration) *Registry {
+	return &Registry{
+		servers: make(map[string]*serverItem),
+		timeout: timeout,
+	}
+}
+
+var DefaultRegistry = New(defaultTimeout)
+
+func (r *Registry) putServer(addr string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.servers[addr]
+	if s == nil {
+		r.servers[addr] = &serverItem{Addr: addr, start: time.Now()}
+		return
+	}
+	s.start = time.Now() // 已存在则刷新存活时间
+}
+
+// aliveServers 返回当前存活的server地址，顺带清理掉已超时的条目
+func (r *Registry) aliveServers() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var alive []string
+	for addr, s := range r.servers {
+		if r.timeout == 0 || s.start.Add(r.timeout).After(time.Now()) {
+			alive = append(alive, addr)
+		} else {
+			delete(r.servers, addr)
+		}
+	}
+	sort.Strings(alive)
+	return alive
+}
+
+// ServeHTTP 实现http.Handler，GET返回存活server列表，POST用于注册/续期
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case "GET":
+		w.Header().Set("X-Rpc-Servers", strings.Join(r.aliveServers(), ","))
+	case "POST":
+		addr := req.Header.Get("X-Rpc-Server")
+		if addr == "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		r.putServer(addr)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleHTTP 将注册中心挂载到registryPath
+func (r *Registry) HandleHTTP(registryPath string) {
+	http.Handle(registryPath, r)
+	log.Println("rpc registry path:", registryPath)
+}
+
+// HandleHTTP 使用默认路径挂载DefaultRegistry
+func HandleHTTP() {
+	DefaultRegistry.HandleHTTP(defaultPath)
+}
+
+// Heartbeat 便于server端启动后周期性地向registryAddr发送心跳，保持自己不被剔除
+func Heartbeat(registryAddr, addr string, duration time.Duration) {
+	if duration == 0 {
+		// 确保在被registry剔除之前，有足够的时间发送下一次心跳
+		duration = defaultTimeout - time.Minute
+	}
+	var err error
+	err = sendHeartbeat(registryAddr, addr)
+	go func() {
+		t := time.NewTicker(duration)
+		for err == nil {
+			<-t.C
+			err = sendHeartbeat(registryAddr, addr)
+		}
+	}()
+}
+
+func sendHeartbeat(registryAddr, addr string) error {
+	log.Println(addr, "send heart beat to registry", registryAddr)
+	httpClient := &http.Client{}
+	req, _ := http.NewRequest("POST", registryAddr, nil)
+	req.Header.Set("X-Rpc-Server", addr)
+	if _, err := httpClient.Do(req); err != nil {
+		log.Println("rpc server: heart beat err:", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package registry
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRegistry_PutAndAliveServers(t *testing.T) {
+	r := New(50 * time.Millisecond)
+	r.putServer("tcp@localhost:1001")
+	r.putServer("tcp@localhost:1002")
+
+	alive := r.aliveServers()
+	if len(alive) != 2 || alive[0] != "tcp@localhost:1001" || alive[1] != "tcp@localhost:1002" {
+		t.Fatalf("aliveServers = %v, want both servers sorted", alive)
+	}
+
+	// 心跳超时后应被aliveServers清理掉
+	time.Sleep(80 * time.Millisecond)
+	alive = r.aliveServers()
+	if len(alive) != 0 {
+		t.Fatalf("aliveServers = %v, want expired servers pruned", alive)
+	}
+	if _, ok := r.servers["tcp@localhost:1001"]; ok {
+		t.Fatal("aliveServers should have deleted the expired entry from r.servers")
+	}
+}
+
+func TestRegistry_PutServer_RefreshesExistingEntry(t *testing.T) {
+	r := New(50 * time.Millisecond)
+	r.putServer("tcp@localhost:1001")
+	time.Sleep(30 * time.Millisecond)
+	r.putServer("tcp@localhost:1001") // 续期，不应视为新条目
+
+	time.Sleep(30 * time.Millisecond)
+	alive := r.aliveServers()
+	if len(alive) != 1 {
+		t.Fatalf("aliveServers = %v, want the renewed server to still be alive", alive)
+	}
+}
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	r := New(time.Minute)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	if err := sendHeartbeat(ts.URL, "tcp@localhost:2001"); err != nil {
+		t.Fatalf("sendHeartbeat: %v", err)
+	}
+
+	resp, err := ts.Client().Get(ts.URL)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("X-Rpc-Servers"); got != "tcp@localhost:2001" {
+		t.Fatalf("X-Rpc-Servers = %q, want %q", got, "tcp@localhost:2001")
+	}
+}
+
+func TestRegistry_ServeHTTP_PostWithoutAddr(t *testing.T) {
+	r := New(time.Minute)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, err := ts.Client().Post(ts.URL, "text/plain", nil)
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 500 {
+		t.Fatalf("status = %d, want 500 when X-Rpc-Server header is missing", resp.StatusCode)
+	}
+}
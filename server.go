@@ -2,13 +2,17 @@ package rpc_demo
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
 	"log"
 	"net"
 	"reflect"
-	"rpc_demo/codec"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"rpc_demo/codec"
 )
 
 const MagicNumber = 0xffff7777
@@ -17,21 +21,27 @@ const MagicNumber = 0xffff7777
 // 为实现简单，固定采用 JSON 编码 Option，后续的 header 和 body 的编码方式由 Option 中的 CodeType 指定，
 // 服务端首先使用 JSON 解码 Option，然后通过 Option 的 CodeType 解码剩余的内容
 type Option struct {
-	MagicNumber int
-	CodecType   codec.Type // 指定消息编解码格式
+	MagicNumber    int
+	CodecType      codec.Type    // 指定消息编解码格式
+	ConnectTimeout time.Duration // 0 表示不限制
+	HandleTimeout  time.Duration // 0 表示不限制
 }
 
 var DefaultOption = &Option{
-	MagicNumber: MagicNumber,
-	CodecType:   codec.JsonType,
+	MagicNumber:    MagicNumber,
+	CodecType:      codec.JsonType,
+	ConnectTimeout: time.Second * 10,
 }
 
 type Server struct {
+	serviceMap sync.Map // 保存已注册的服务，key为服务名
 }
 
 type request struct {
 	h            *codec.Header
 	argv, replyv reflect.Value
+	mtype        *methodType
+	svc          *service
 }
 
 func NewServer() *Server {
@@ -40,6 +50,55 @@ func NewServer() *Server {
 
 var DefaultServer = NewServer()
 
+// Register 将rcvr的方法注册到服务端，服务名取rcvr的类型名
+func (server *Server) Register(rcvr interface{}) error {
+	s := newService(rcvr, "")
+	if _, dup := server.serviceMap.LoadOrStore(s.name, s); dup {
+		return errors.New("rpc: service already defined: " + s.name)
+	}
+	return nil
+}
+
+// Register 在DefaultServer上注册服务，方便用户调用
+func Register(rcvr interface{}) error {
+	return DefaultServer.Register(rcvr)
+}
+
+// RegisterName 将rcvr的方法以指定的name注册到服务端
+func (server *Server) RegisterName(name string, rcvr interface{}) error {
+	s := newService(rcvr, name)
+	if _, dup := server.serviceMap.LoadOrStore(s.name, s); dup {
+		return errors.New("rpc: service already defined: " + s.name)
+	}
+	return nil
+}
+
+// RegisterName 在DefaultServer上注册服务，方便用户调用
+func RegisterName(name string, rcvr interface{}) error {
+	return DefaultServer.RegisterName(name, rcvr)
+}
+
+// findService 将ServiceMethod按最后一个'.'拆分为服务名和方法名，并在serviceMap中查找
+func (server *Server) findService(serviceMethod string) (svc *service, mtype *methodType, err error) {
+	dot := strings.LastIndex(serviceMethod, ".")
+	if dot < 0 {
+		err = errors.New("rpc server: service/method request ill-formed: " + serviceMethod)
+		return
+	}
+	serviceName, methodName := serviceMethod[:dot], serviceMethod[dot+1:]
+	svci, ok := server.serviceMap.Load(serviceName)
+	if !ok {
+		err = errors.New("rpc server: can't find service " + serviceName)
+		return
+	}
+	svc = svci.(*service)
+	mtype = svc.method[methodName]
+	if mtype == nil {
+		err = errors.New("rpc server: can't find method " + methodName)
+	}
+	return
+}
+
 // Accept 经典Accept函数，循环等待socket连接，开启协程进行处理
 func (server *Server) Accept(lis net.Listener) {
 	for {
@@ -57,11 +116,27 @@ func Accept(lis net.Listener) {
 	DefaultServer.Accept(lis)
 }
 
+// bufferedConn 把Read转交给一个固定的io.Reader，Write/Close仍直接操作原始conn
+type bufferedConn struct {
+	io.Reader
+	conn io.ReadWriteCloser
+}
+
+func (b *bufferedConn) Write(p []byte) (int, error) { return b.conn.Write(p) }
+func (b *bufferedConn) Close() error                { return b.conn.Close() }
+
 func (server *Server) ServeConn(conn io.ReadWriteCloser) {
 	defer func() { _ = conn.Close() }()
-	var opt Option
 	// 根据事先约定用json反序列化获取Option实例，并进行参数后续的参数检查
-	if err := json.NewDecoder(conn).Decode(&opt); err != nil {
+	// 无论CodecType是什么，Option本身都固定用JSON编码，后续的header/body才切换到对应Codec。
+	// json.Decoder内部维护自己的缓冲区，一次底层Read可能把紧随Option之后发来的
+	// header/body也一并读了进来；Decode(&opt)只会消费掉Option那部分，剩下的字节
+	// 留在dec.Buffered()里——若在此直接丢弃dec、对conn再建一个新的Decoder，这部分
+	// 字节就会永久丢失。用dec.Buffered()和conn拼出的MultiReader把这部分字节交还
+	// 给后续的codec，避免请求被丢包导致永久读不到。
+	dec := json.NewDecoder(conn)
+	var opt Option
+	if err := dec.Decode(&opt); err != nil {
 		log.Println("rpc server: options error: ", err)
 		return
 	}
@@ -75,13 +150,12 @@ func (server *Server) ServeConn(conn io.ReadWriteCloser) {
 		log.Println("rpc server: invalid codec type: ", opt.CodecType)
 		return
 	}
-	// 进行相关编解码业务处理
-	server.serveCodec(f(conn))
+	server.serveCodec(f(&bufferedConn{Reader: io.MultiReader(dec.Buffered(), conn), conn: conn}), &opt)
 }
 
 var invalidRequest = struct{}{}
 
-func (server *Server) serveCodec(cc codec.Codec) {
+func (server *Server) serveCodec(cc codec.Codec, opt *Option) {
 	// 因为用go routine并发处理请求，但回复请求的报文必须要求顺序返回，
 	// 所以引入锁来保证回复报文的有序性
 	sending := &sync.Mutex{}
@@ -101,7 +175,7 @@ func (server *Server) serveCodec(cc codec.Codec) {
 		}
 		wg.Add(1)
 		// 处理请求
-		go server.handleRequest(cc, req, sending, wg)
+		go server.handleRequest(cc, req, sending, wg, opt.HandleTimeout)
 	}
 	wg.Wait()
 	_ = cc.Close()
@@ -124,12 +198,23 @@ func (server *Server) readRequest(cc codec.Codec) (*request, error) {
 		return nil, err
 	}
 	req := &request{h: h}
-	// TODO 请求参数类型未判断
-	req.argv = reflect.New(reflect.TypeOf(""))
-	if err = cc.ReadBody(req.argv.Interface()); err != nil {
+	req.svc, req.mtype, err = server.findService(h.ServiceMethod)
+	if err != nil {
+		return req, err
+	}
+	req.argv = req.mtype.newArgv()
+	req.replyv = req.mtype.newReplyv()
+
+	// argv 可能是指针类型，也可能是值类型，ReadBody 需要拿到指针
+	argvi := req.argv.Interface()
+	if req.argv.Kind() != reflect.Ptr {
+		argvi = req.argv.Addr().Interface()
+	}
+	if err = cc.ReadBody(argvi); err != nil {
 		log.Println("rpc server: read argv err: ", err)
+		return req, err
 	}
-	return req, err
+	return req, nil
 }
 
 func (server *Server) sendResponse(cc codec.Codec, h *codec.Header, body interface{}, sending *sync.Mutex) {
@@ -139,11 +224,44 @@ func (server *Server) sendResponse(cc codec.Codec, h *codec.Header, body interfa
 		log.Println("rpc server: write response error: ", err)
 	}
 }
-func (server *Server) handleRequest(cc codec.Codec, req *request, sending *sync.Mutex, wg *sync.WaitGroup) {
+func (server *Server) handleRequest(cc codec.Codec, req *request, sending *sync.Mutex, wg *sync.WaitGroup, timeout time.Duration) {
 	defer wg.Done()
-	log.Println(req.h, req.argv.Elem())
-	// 接收到请求进行简单print
-	// TODO 后续完整实现
-	req.replyv = reflect.ValueOf(fmt.Sprintf("rpc resp %d", req.h.Seq))
-	server.sendResponse(cc, req.h, req.replyv.Interface(), sending)
+	// called/sent必须带缓冲：超时分支一旦命中time.After就不会再接收这两个channel，
+	// 若是无缓冲的，done goroutine会永久阻塞在对应的send上而泄漏
+	called := make(chan struct{}, 1)
+	sent := make(chan struct{}, 1)
+	// responded用CAS保证超时分支和正常返回分支之间只有一个真正写出响应：
+	// 请求超时后，svc.call最终还是会返回，不能让这个迟到的结果再发一帧同seq的响应
+	var responded int32
+	go func() {
+		err := req.svc.call(req.mtype, req.argv, req.replyv)
+		called <- struct{}{}
+		if !atomic.CompareAndSwapInt32(&responded, 0, 1) {
+			sent <- struct{}{}
+			return
+		}
+		if err != nil {
+			req.h.Error = err.Error()
+			server.sendResponse(cc, req.h, invalidRequest, sending)
+			sent <- struct{}{}
+			return
+		}
+		server.sendResponse(cc, req.h, req.replyv.Interface(), sending)
+		sent <- struct{}{}
+	}()
+
+	if timeout == 0 {
+		<-called
+		<-sent
+		return
+	}
+	select {
+	case <-time.After(timeout):
+		if atomic.CompareAndSwapInt32(&responded, 0, 1) {
+			req.h.Error = "rpc server: request handle timeout"
+			server.sendResponse(cc, req.h, invalidRequest, sending)
+		}
+	case <-called:
+		<-sent
+	}
 }
@@ -0,0 +1,43 @@
+package rpc_demo
+
+import (
+	"io"
+	"log"
+	"net/http"
+)
+
+const (
+	connected        = "200 Connected to RPC"
+	defaultRPCPath   = "/_rpc_"
+	defaultDebugPath = "/debug/rpc"
+)
+
+// ServeHTTP 实现http.Handler接口，只接受CONNECT请求，hijack连接后交给ServeConn处理，
+// 从而使RPC可以和其他HTTP接口共用同一个端口
+func (server *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "CONNECT" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		_, _ = io.WriteString(w, "405 must CONNECT\n")
+		return
+	}
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		log.Print("rpc hijacking ", req.RemoteAddr, ": ", err.Error())
+		return
+	}
+	_, _ = io.WriteString(conn, "HTTP/1.0 "+connected+"\n\n")
+	server.ServeConn(conn)
+}
+
+// HandleHTTP 将RPC请求注册到rpcPath，调试信息注册到debugPath，
+// 这样就可以将server挂载到一个已有的http.ServeMux上
+func (server *Server) HandleHTTP(rpcPath, debugPath string) {
+	http.Handle(rpcPath, server)
+	http.Handle(debugPath, debugHTTP{server})
+}
+
+// HandleHTTP 在DefaultServer上使用默认的rpcPath和debugPath注册
+func HandleHTTP() {
+	DefaultServer.HandleHTTP(defaultRPCPath, defaultDebugPath)
+}
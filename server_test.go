@@ -2,7 +2,6 @@ package rpc_demo
 
 import (
 	"encoding/json"
-	"fmt"
 	"log"
 	"net"
 	"testing"
@@ -12,7 +11,24 @@ import (
 	"rpc_demo/codec"
 )
 
+// Foo 测试用的RPC服务
+type Foo int
+
+// Args Foo.Sum的入参
+type Args struct {
+	Num1, Num2 int
+}
+
+func (f Foo) Sum(args Args, reply *int) error {
+	*reply = args.Num1 + args.Num2
+	return nil
+}
+
 func startServer(addr chan string) {
+	var foo Foo
+	// 多个测试共用DefaultServer，重复注册忽略即可
+	_ = Register(&foo)
+
 	// pick a free port
 	l, err := net.Listen("tcp", ":0")
 	if err != nil {
@@ -40,12 +56,12 @@ func TestServer_ServeConn(t *testing.T) {
 					ServiceMethod: "Foo.Sum",
 					Seq:           uint64(i),
 				}
-				_ = cc.Write(h, fmt.Sprintf("rpc req %d", h.Seq))
+				_ = cc.Write(h, Args{Num1: i, Num2: i * i})
 				_ = cc.ReadHeader(h)
-				var reply string
+				var reply int
 				_ = cc.ReadBody(&reply)
 				log.Println("reply:", reply)
-				So(reply, ShouldNotEqual, nil)
+				So(reply, ShouldEqual, i+i*i)
 			}
 		})
 	})
@@ -0,0 +1,114 @@
+package rpc_demo
+
+import (
+	"go/ast"
+	"log"
+	"reflect"
+	"sync/atomic"
+)
+
+// methodType 一个服务方法的元信息
+type methodType struct {
+	method    reflect.Method // 方法本身
+	ArgType   reflect.Type   // 第一个入参的类型
+	ReplyType reflect.Type   // 第二个入参（回复）的类型
+	numCalls  uint64         // 统计方法调用次数
+}
+
+func (m *methodType) NumCalls() uint64 {
+	return atomic.LoadUint64(&m.numCalls)
+}
+
+// newArgv 创建ArgType类型的实例，兼容指针和值两种类型
+func (m *methodType) newArgv() reflect.Value {
+	var argv reflect.Value
+	if m.ArgType.Kind() == reflect.Ptr {
+		argv = reflect.New(m.ArgType.Elem())
+	} else {
+		argv = reflect.New(m.ArgType).Elem()
+	}
+	return argv
+}
+
+// newReplyv 创建ReplyType类型的实例，ReplyType一定是指针类型
+func (m *methodType) newReplyv() reflect.Value {
+	replyv := reflect.New(m.ReplyType.Elem())
+	switch m.ReplyType.Elem().Kind() {
+	case reflect.Map:
+		replyv.Elem().Set(reflect.MakeMap(m.ReplyType.Elem()))
+	case reflect.Slice:
+		replyv.Elem().Set(reflect.MakeSlice(m.ReplyType.Elem(), 0, 0))
+	}
+	return replyv
+}
+
+// service 某个结构体（接收者）在RPC中的映射，保存其可导出的方法
+type service struct {
+	name   string                 // 服务名，即结构体名
+	typ    reflect.Type           // 结构体类型
+	rcvr   reflect.Value          // 结构体实例本身
+	method map[string]*methodType // 可映射的方法，key为方法名
+}
+
+// newService name为空时，取rcvr的类型名作为服务名
+func newService(rcvr interface{}, name string) *service {
+	s := new(service)
+	s.rcvr = reflect.ValueOf(rcvr)
+	s.typ = reflect.TypeOf(rcvr)
+	if name == "" {
+		name = reflect.Indirect(s.rcvr).Type().Name()
+	}
+	s.name = name
+	if !ast.IsExported(s.name) {
+		log.Fatalf("rpc server: %s is not a valid service name", s.name)
+	}
+	s.registerMethods()
+	return s
+}
+
+// registerMethods 过滤出符合 func (t *T) MethodName(argType T1, replyType *T2) error 签名的方法
+func (s *service) registerMethods() {
+	s.method = make(map[string]*methodType)
+	for i := 0; i < s.typ.NumMethod(); i++ {
+		method := s.typ.Method(i)
+		mType := method.Type
+		// 两个导出或内置类型的入参（反射下第0个入参是接收者自身），一个error类型的返回值
+		if mType.NumIn() != 3 || mType.NumOut() != 1 {
+			continue
+		}
+		if mType.Out(0) != reflect.TypeOf((*error)(nil)).Elem() {
+			continue
+		}
+		argType, replyType := mType.In(1), mType.In(2)
+		if replyType.Kind() != reflect.Ptr {
+			continue
+		}
+		if !isExportedOrBuiltinType(argType) || !isExportedOrBuiltinType(replyType) {
+			continue
+		}
+		s.method[method.Name] = &methodType{
+			method:    method,
+			ArgType:   argType,
+			ReplyType: replyType,
+		}
+		log.Printf("rpc server: register %s.%s\n", s.name, method.Name)
+	}
+}
+
+func isExportedOrBuiltinType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return ast.IsExported(t.Name()) || t.PkgPath() == ""
+}
+
+// call 通过反射调用方法，并统计调用次数
+func (s *service) call(m *methodType, argv, replyv reflect.Value) error {
+	atomic.AddUint64(&m.numCalls, 1)
+	f := m.method.Func
+	returnValues := f.Call([]reflect.Value{s.rcvr, argv, replyv})
+	if errInter := returnValues[0].Interface(); errInter != nil {
+		return errInter.(error)
+	}
+	return nil
+}